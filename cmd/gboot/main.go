@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/wangchaoHZ/gboot/discover"
+	"github.com/wangchaoHZ/gboot/manifest"
+	"github.com/wangchaoHZ/gboot/transport"
+)
+
+const (
+	VERSION = "1.2.0"
+)
+
+// buildTransport resolves the `-transport` flag to a concrete Transport.
+// tcp pushes the image to serverIP; tftp puts gboot into server mode and
+// waits for the device's bootloader to pull it. code is the shared PAKE
+// password for a secure session (-code); it is ignored by the tftp backend,
+// which has no secure mode.
+func buildTransport(kind, serverIP, code string) (transport.Transport, error) {
+	switch kind {
+	case "", "tcp":
+		tcp := transport.NewTCP(serverIP)
+		tcp.Code = code
+		return tcp, nil
+	case "tftp":
+		return transport.NewTFTP(""), nil
+	default:
+		return nil, fmt.Errorf("unknown transport %q (want tcp or tftp)", kind)
+	}
+}
+
+func sendFirmware(firmwareFile, serverIP, transportKind, code string) {
+	file, err := os.Open(firmwareFile)
+	if err != nil {
+		fmt.Printf("Error: Firmware file %s not found\n", firmwareFile)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		fmt.Println("Error: Failed to stat firmware file:", err)
+		return
+	}
+
+	tr, err := buildTransport(transportKind, serverIP, code)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	if err := tr.Send(context.Background(), filepath.Base(firmwareFile), "", file, info.Size()); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// sendImage uploads a single manifest entry via tr, tagging it with its
+// slot so a slot-aware gbootd can route it to the matching sink.
+func sendImage(ctx context.Context, tr transport.Transport, img manifest.Image) error {
+	file, err := os.Open(img.Path)
+	if err != nil {
+		return fmt.Errorf("image %q: file %s not found: %w", img.Name, img.Path, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("image %q: failed to stat %s: %w", img.Name, img.Path, err)
+	}
+
+	fmt.Printf("Sending image %q (slot %s, target %s)\n", img.Name, img.Slot, img.Target)
+	return tr.Send(ctx, img.Name, img.Slot, file, info.Size())
+}
+
+// sendManifest flashes every image listed in the manifest at manifestPath
+// to serverIP, in manifest order.
+func sendManifest(manifestPath, serverIP, transportKind, code string) {
+	images, err := manifest.Load(manifestPath)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	tr, err := buildTransport(transportKind, serverIP, code)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	ctx := context.Background()
+	for _, img := range images {
+		if err := sendImage(ctx, tr, img); err != nil {
+			fmt.Printf("Error: Failed to send image %q: %v\n", img.Name, err)
+			return
+		}
+	}
+}
+
+// resolveTarget accepts either a literal IPv4/IPv6 address or a device name
+// discovered on the LAN via the discover package.
+func resolveTarget(target string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return discover.Resolve(ctx, target)
+}
+
+func listDevices() {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	devices, err := discover.Discover(ctx, 2*time.Second)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	if len(devices) == 0 {
+		fmt.Println("No devices found.")
+		return
+	}
+	for _, dev := range devices {
+		fmt.Printf("%s\t%s:%d\t%s\n", dev.Name, dev.IP, dev.Port, dev.Board)
+	}
+}
+
+func usage() {
+	fmt.Println("Usage: gboot [-transport tcp|tftp] [-code <shared-secret>] <firmware_file> <server_ip_or_name>")
+	fmt.Println("       gboot [-transport tcp|tftp] [-code <shared-secret>] -manifest <images.toml> <server_ip_or_name>")
+	fmt.Println("       gboot -list  (discover devices on the LAN)")
+	fmt.Println("       gboot version  or  gboot -v  (to check version)")
+	fmt.Println()
+	fmt.Println("-code enables an authenticated, encrypted session (TCP transport only):")
+	fmt.Println("a PAKE handshake derives a session key from the shared secret, so it")
+	fmt.Println("never crosses the network. Both sides must pass the same -code.")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	args := os.Args[1:]
+
+	if args[0] == "version" || args[0] == "-v" {
+		fmt.Printf("Firmware Sender Version: %s\n", VERSION)
+		return
+	}
+
+	if args[0] == "-list" {
+		listDevices()
+		return
+	}
+
+	transportKind := "tcp"
+	code := ""
+	for len(args) >= 2 && (args[0] == "-transport" || args[0] == "-code") {
+		switch args[0] {
+		case "-transport":
+			transportKind = args[1]
+		case "-code":
+			code = args[1]
+		}
+		args = args[2:]
+	}
+
+	if len(args) == 3 && args[0] == "-manifest" {
+		serverIP, err := resolveTarget(args[2])
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		sendManifest(args[1], serverIP, transportKind, code)
+		return
+	}
+
+	if len(args) != 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	serverIP, err := resolveTarget(args[1])
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	sendFirmware(args[0], serverIP, transportKind, code)
+}
@@ -0,0 +1,85 @@
+// Command gbootd is the device-side counterpart to gboot: it accepts
+// uploads over the gboot protocol and writes each verified image to a
+// configurable sink — a raw block device, a plain file, or a completion
+// hook to exec with the image.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/wangchaoHZ/gboot/pkg/gboot"
+)
+
+// sinkPaths collects one or more -sink flags into a slot -> path map. A
+// bare path (no "=") is stored under slot "", the default used by a
+// single-image (non-manifest) send; `-sink bootloader=/dev/mmcblk0p0` routes
+// that one manifest slot to its own device or file.
+type sinkPaths map[string]string
+
+func (p sinkPaths) String() string {
+	parts := make([]string, 0, len(p))
+	for slot, path := range p {
+		if slot == "" {
+			parts = append(parts, path)
+			continue
+		}
+		parts = append(parts, slot+"="+path)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (p sinkPaths) Set(v string) error {
+	slot, path, ok := strings.Cut(v, "=")
+	if !ok {
+		slot, path = "", v
+	}
+	p[slot] = path
+	return nil
+}
+
+func main() {
+	addr := flag.String("addr", ":5000", "address to listen on")
+	sinks := make(sinkPaths)
+	flag.Var(sinks, "sink", "file or block device path to write a verified image to (e.g. /dev/mmcblk0p1); "+
+		"repeat as slot=path (e.g. -sink bootloader=/dev/mmcblk0p0) to route a manifest's slots to separate sinks")
+	onComplete := flag.String("on-complete", "", "exec this hook with the verified image's path and slot instead of writing to -sink")
+	code := flag.String("code", "", "shared PAKE secret required to establish a secure session")
+	insecure := flag.Bool("insecure", true, "allow clients with no -code to connect in cleartext")
+	flag.Parse()
+
+	if (len(sinks) == 0) == (*onComplete == "") {
+		fmt.Fprintln(os.Stderr, "gbootd: specify exactly one of -sink or -on-complete")
+		os.Exit(1)
+	}
+	if *code == "" && !*insecure {
+		fmt.Fprintln(os.Stderr, "gbootd: -insecure=false requires -code to be set")
+		os.Exit(1)
+	}
+
+	newSink := func(sessionID, slot string) (gboot.Sink, error) {
+		if *onComplete != "" {
+			return gboot.NewExecSink(*onComplete, slot)
+		}
+		path, ok := sinks[slot]
+		if !ok {
+			return nil, fmt.Errorf("gbootd: no -sink configured for slot %q", slot)
+		}
+		return gboot.NewFileSink(path)
+	}
+
+	srv, err := gboot.NewServer(*addr, newSink)
+	if err != nil {
+		log.Fatalf("gbootd: %v", err)
+	}
+	srv.Code = *code
+	srv.InsecureAllowed = *insecure
+
+	log.Printf("gbootd: listening on %s", *addr)
+	if err := srv.Serve(); err != nil {
+		log.Fatalf("gbootd: %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+// Package discover finds bootable gboot targets on the LAN, so users don't
+// have to hardcode a device's IPv4 address. Two mechanisms are supported: a
+// lightweight UDP broadcast probe, and mDNS/DNS-SD browsing for devices that
+// advertise a `_gboot._tcp.local` service (mirroring Fuchsia's netboot
+// discovery).
+package discover
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/mdns"
+)
+
+const (
+	// BroadcastPort is the UDP port in-bootloader devices listen on for probes.
+	BroadcastPort = 5001
+	probeMsg      = "GBOOT?"
+	replyPrefix   = "GBOOT!"
+	mdnsService   = "_gboot._tcp"
+)
+
+// Device is one discovered gboot-capable target.
+type Device struct {
+	Name  string
+	IP    string
+	Port  int
+	Board string
+}
+
+// Discover runs both the broadcast probe and mDNS browse in parallel and
+// returns the de-duplicated (by name) union of what answered within timeout.
+func Discover(ctx context.Context, timeout time.Duration) ([]Device, error) {
+	type result struct {
+		devices []Device
+		err     error
+	}
+	results := make(chan result, 2)
+
+	go func() {
+		d, err := Probe(ctx, timeout)
+		results <- result{d, err}
+	}()
+	go func() {
+		d, err := Browse(ctx, timeout)
+		results <- result{d, err}
+	}()
+
+	seen := make(map[string]Device)
+	var firstErr error
+	for i := 0; i < 2; i++ {
+		r := <-results
+		if r.err != nil && firstErr == nil {
+			firstErr = r.err
+		}
+		for _, dev := range r.devices {
+			seen[dev.Name] = dev
+		}
+	}
+
+	devices := make([]Device, 0, len(seen))
+	for _, dev := range seen {
+		devices = append(devices, dev)
+	}
+	if len(devices) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	return devices, nil
+}
+
+// Probe broadcasts a GBOOT? datagram on BroadcastPort and collects
+// GBOOT!<name>:<ip>:<port>:<board> replies until timeout elapses.
+func Probe(ctx context.Context, timeout time.Duration) ([]Device, error) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: 0})
+	if err != nil {
+		return nil, fmt.Errorf("discover: failed to open broadcast socket: %w", err)
+	}
+	defer conn.Close()
+
+	broadcastAddr := &net.UDPAddr{IP: net.IPv4bcast, Port: BroadcastPort}
+	if _, err := conn.WriteToUDP([]byte(probeMsg), broadcastAddr); err != nil {
+		return nil, fmt.Errorf("discover: failed to send probe: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	conn.SetReadDeadline(deadline)
+
+	var devices []Device
+	buf := make([]byte, 512)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			break // timeout: return whatever answered so far
+		}
+		if dev, ok := parseReply(string(buf[:n])); ok {
+			devices = append(devices, dev)
+		}
+	}
+	return devices, nil
+}
+
+// parseReply decodes `GBOOT!<name>:<ip>:<port>:<board>`.
+func parseReply(msg string) (Device, bool) {
+	if !strings.HasPrefix(msg, replyPrefix) {
+		return Device{}, false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(msg, replyPrefix), ":", 4)
+	if len(parts) != 4 {
+		return Device{}, false
+	}
+	port, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return Device{}, false
+	}
+	return Device{Name: parts[0], IP: parts[1], Port: port, Board: parts[3]}, true
+}
+
+// Browse looks for devices advertising `_gboot._tcp.local` over mDNS/DNS-SD.
+func Browse(ctx context.Context, timeout time.Duration) ([]Device, error) {
+	entries := make(chan *mdns.ServiceEntry, 16)
+	var devices []Device
+	done := make(chan struct{})
+
+	go func() {
+		for entry := range entries {
+			devices = append(devices, Device{
+				Name:  entry.Name,
+				IP:    entry.AddrV4.String(),
+				Port:  entry.Port,
+				Board: entry.Info,
+			})
+		}
+		close(done)
+	}()
+
+	params := mdns.DefaultParams(mdnsService)
+	params.Entries = entries
+	params.Timeout = timeout
+	if err := mdns.Query(params); err != nil {
+		close(entries)
+		return nil, fmt.Errorf("discover: mDNS browse failed: %w", err)
+	}
+	close(entries)
+	<-done
+	return devices, nil
+}
+
+// Resolve turns a name or literal IP into an address to dial. If target
+// already parses as an IP (v4 or v6), it's returned unchanged; otherwise the
+// LAN is searched for a device advertising that name.
+func Resolve(ctx context.Context, target string) (string, error) {
+	if net.ParseIP(target) != nil {
+		return target, nil
+	}
+
+	devices, err := Discover(ctx, 2*time.Second)
+	if err != nil {
+		return "", fmt.Errorf("discover: failed to resolve %q: %w", target, err)
+	}
+	for _, dev := range devices {
+		if dev.Name == target {
+			return dev.IP, nil
+		}
+	}
+	return "", fmt.Errorf("discover: no device named %q found on the LAN", target)
+}
@@ -0,0 +1,44 @@
+// Package manifest parses the TOML image list used by `gboot -manifest`,
+// allowing a single invocation to flash several named images (bootloader,
+// kernel, rootfs, vbmeta, ...) into their respective slots in one session.
+package manifest
+
+import (
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Image describes one entry in a manifest file.
+type Image struct {
+	Name   string `toml:"name"`
+	Path   string `toml:"path"`
+	Slot   string `toml:"slot"`
+	Target string `toml:"target"`
+}
+
+// file is the on-disk TOML shape: a list of [[image]] tables.
+type file struct {
+	Image []Image `toml:"image"`
+}
+
+// Load reads and validates the manifest at path, returning the ordered list
+// of images to upload.
+func Load(path string) ([]Image, error) {
+	var f file
+	if _, err := toml.DecodeFile(path, &f); err != nil {
+		return nil, fmt.Errorf("manifest: failed to parse %s: %w", path, err)
+	}
+	if len(f.Image) == 0 {
+		return nil, fmt.Errorf("manifest: %s declares no images", path)
+	}
+	for i, img := range f.Image {
+		if img.Name == "" {
+			return nil, fmt.Errorf("manifest: image %d in %s is missing a name", i, path)
+		}
+		if img.Path == "" {
+			return nil, fmt.Errorf("manifest: image %q in %s is missing a path", img.Name, path)
+		}
+	}
+	return f.Image, nil
+}
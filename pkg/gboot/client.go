@@ -0,0 +1,160 @@
+package gboot
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// Client drives one upload session over an already-connected conn.
+type Client struct {
+	Conn       net.Conn
+	AckTimeout time.Duration
+}
+
+// NewClient wraps conn with the default ACK timeout.
+func NewClient(conn net.Conn) *Client {
+	return &Client{Conn: conn, AckTimeout: 5 * time.Second}
+}
+
+// Negotiate sends the session header and returns how many leading chunks
+// the server already holds, which is the index of the first chunk to send
+// (0 for a fresh session). secure
+// should be true when c.Conn is a secure.Conn, so the server knows to
+// verify the trailing SHA-256 digest instead of a CRC32. slot identifies
+// which manifest slot this image belongs to, so a slot-aware server can
+// route it to the right sink; it's empty for a single-image send.
+func (c *Client) Negotiate(size int64, dataCRC32 uint32, sessionID string, secure bool, slot string) (int, error) {
+	if err := WriteHeader(c.Conn, Header{Size: size, CRC32: dataCRC32, ChunkSize: ChunkSize, SessionID: sessionID, Secure: secure, Slot: slot}); err != nil {
+		return 0, fmt.Errorf("gboot: failed to send header: %w", err)
+	}
+	lastAcked, err := ReadLastAcked(c.Conn)
+	if err != nil {
+		return 0, fmt.Errorf("gboot: failed to read resume offset: %w", err)
+	}
+	return lastAcked, nil
+}
+
+// SendChunks pipelines data in WindowSize-deep windows of chunk frames,
+// starting at startChunk. A NAK retransmits just the chunk it names; an ACK
+// read timeout retransmits the whole outstanding window (a flaky link
+// dropping the ACK itself, rather than the data, is the case this recovers
+// from).
+// onAcked is called every time a chunk is confirmed, with the bytes just
+// confirmed and the current contiguous-ack frontier (in chunks), so callers
+// can drive a progress bar and persist resume state.
+func (c *Client) SendChunks(data []byte, startChunk int, onAcked func(ackedBytes, frontier int)) error {
+	totalChunks := (len(data) + ChunkSize - 1) / ChunkSize
+
+	nextToSend := startChunk
+	acked := startChunk
+	outstanding := make(map[uint32][]byte)
+
+	chunkAt := func(index int) []byte {
+		start := index * ChunkSize
+		end := start + ChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		return data[start:end]
+	}
+
+	sendOne := func(index int) error {
+		payload := chunkAt(index)
+		if err := WriteChunkFrame(c.Conn, uint32(index), payload); err != nil {
+			return err
+		}
+		outstanding[uint32(index)] = payload
+		return nil
+	}
+
+	c.Conn.SetReadDeadline(time.Now().Add(c.AckTimeout))
+
+	for acked < totalChunks {
+		for nextToSend < totalChunks && len(outstanding) < WindowSize {
+			if err := sendOne(nextToSend); err != nil {
+				return fmt.Errorf("gboot: failed to send chunk %d: %w", nextToSend, err)
+			}
+			nextToSend++
+		}
+
+		typ, index, err := ReadAck(c.Conn)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				for idx, payload := range outstanding {
+					if err := WriteChunkFrame(c.Conn, idx, payload); err != nil {
+						return fmt.Errorf("gboot: failed to retransmit chunk %d after timeout: %w", idx, err)
+					}
+				}
+				c.Conn.SetReadDeadline(time.Now().Add(c.AckTimeout))
+				continue
+			}
+			return fmt.Errorf("gboot: did not receive ACK/NAK: %w", err)
+		}
+		c.Conn.SetReadDeadline(time.Now().Add(c.AckTimeout))
+
+		switch typ {
+		case AckOK:
+			payload, ok := outstanding[index]
+			if !ok {
+				continue
+			}
+			delete(outstanding, index)
+			if int(index) == acked {
+				acked++
+				for {
+					if _, stillOut := outstanding[uint32(acked)]; stillOut {
+						break
+					}
+					if acked >= nextToSend {
+						break
+					}
+					acked++
+				}
+			}
+			if onAcked != nil {
+				onAcked(len(payload), acked)
+			}
+		case AckNAK:
+			if payload, ok := outstanding[index]; ok {
+				if err := WriteChunkFrame(c.Conn, index, payload); err != nil {
+					return fmt.Errorf("gboot: failed to retransmit chunk %d: %w", index, err)
+				}
+			}
+		}
+	}
+	c.Conn.SetReadDeadline(time.Time{})
+	return nil
+}
+
+// Finish sends the trailing whole-image CRC32 and waits for the server's
+// verification reply.
+func (c *Client) Finish(dataCRC32 uint32) error {
+	frame := make([]byte, 4)
+	binary.BigEndian.PutUint32(frame, dataCRC32)
+	return c.finish(frame)
+}
+
+// FinishSecure sends the trailing whole-image SHA-256 digest used in place
+// of a CRC32 on a secure session, where the AEAD already authenticates the
+// bytes in flight and the digest guards against a key derived from the
+// wrong code ever being mistaken for success.
+func (c *Client) FinishSecure(firmwareSHA256 []byte) error {
+	return c.finish(firmwareSHA256)
+}
+
+func (c *Client) finish(trailer []byte) error {
+	if _, err := c.Conn.Write(trailer); err != nil {
+		return fmt.Errorf("gboot: failed to send final checksum: %w", err)
+	}
+
+	resp := make([]byte, len(CRCOkMsg))
+	if _, err := io.ReadFull(c.Conn, resp); err != nil || string(resp) != CRCOkMsg {
+		return fmt.Errorf("gboot: verification failed, image may be corrupted or tampered with")
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+// Package gboot implements the gboot firmware-upload wire protocol —
+// session negotiation, chunk framing and CRC32 checks — plus the Client and
+// Server types that speak it. cmd/gboot and cmd/gbootd are thin binaries
+// built on top of this package.
+package gboot
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+)
+
+const (
+	// ProtocolVersion is the single byte sent at the start of every session header.
+	ProtocolVersion = 1
+	// ChunkSize is the size in bytes of each framed chunk.
+	ChunkSize = 256
+	// WindowSize is how many chunks a Client may have in flight before an ACK is required.
+	WindowSize = 16
+	// CRCOkMsg is sent by the server once the trailing whole-image CRC32 checks out.
+	CRCOkMsg = "CRC_OK"
+	// CRCBadMsg is sent instead when the trailing CRC32 doesn't match.
+	CRCBadMsg = "BADCRC"
+	// MaxImageSize bounds the Size a Server will accept in a session header,
+	// and so the buffer it allocates on the strength of one unauthenticated
+	// header: large enough for any real firmware/kernel/rootfs image, while
+	// a larger (or negative, via int64 overflow) Size is rejected.
+	MaxImageSize = 4 << 30 // 4 GiB
+
+	sessionIDLen = 16
+)
+
+// AckType identifies what kind of per-chunk reply the server sent.
+type AckType uint8
+
+const (
+	AckOK AckType = iota
+	AckNAK
+)
+
+// Header is the session negotiation sent once per connection, before any
+// chunk frames. It is always sent after any secure.Handshake, so on a
+// secure session it travels encrypted like everything else.
+type Header struct {
+	Size      int64
+	CRC32     uint32
+	ChunkSize uint32
+	SessionID string
+	// Secure records whether this session was established over a
+	// secure.Conn, so both sides know whether Finish should verify a
+	// trailing CRC32 or a SHA-256 digest.
+	Secure bool
+	// Slot identifies which manifest slot (bootloader, kernel, rootfs, ...)
+	// this image belongs to, so a slot-aware Server.NewSink can route it to
+	// the right sink. Empty for a single-image (non-manifest) send.
+	Slot string
+}
+
+const maxSlotLen = 255
+
+// WriteHeader writes the session header to conn.
+func WriteHeader(conn net.Conn, h Header) error {
+	if len(h.Slot) > maxSlotLen {
+		return fmt.Errorf("gboot: slot name %q is longer than %d bytes", h.Slot, maxSlotLen)
+	}
+	buf := make([]byte, 1+8+4+4+1+sessionIDLen+1+len(h.Slot))
+	offset := 0
+	buf[offset] = ProtocolVersion
+	offset++
+	binary.BigEndian.PutUint64(buf[offset:], uint64(h.Size))
+	offset += 8
+	binary.BigEndian.PutUint32(buf[offset:], h.CRC32)
+	offset += 4
+	binary.BigEndian.PutUint32(buf[offset:], h.ChunkSize)
+	offset += 4
+	if h.Secure {
+		buf[offset] = 1
+	}
+	offset++
+	copy(buf[offset:], []byte(h.SessionID))
+	offset += sessionIDLen
+	buf[offset] = byte(len(h.Slot))
+	offset++
+	copy(buf[offset:], []byte(h.Slot))
+	_, err := conn.Write(buf)
+	return err
+}
+
+// ReadHeader reads the session header from conn.
+func ReadHeader(conn net.Conn) (Header, error) {
+	buf := make([]byte, 1+8+4+4+1+sessionIDLen+1)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return Header{}, err
+	}
+	offset := 0
+	version := buf[offset]
+	offset++
+	if version != ProtocolVersion {
+		return Header{}, fmt.Errorf("gboot: unsupported protocol version %d", version)
+	}
+	size := int64(binary.BigEndian.Uint64(buf[offset:]))
+	offset += 8
+	crc := binary.BigEndian.Uint32(buf[offset:])
+	offset += 4
+	chunkSize := binary.BigEndian.Uint32(buf[offset:])
+	offset += 4
+	secure := buf[offset] == 1
+	offset++
+	sessionID := string(buf[offset : offset+sessionIDLen])
+	offset += sessionIDLen
+	slotLen := int(buf[offset])
+
+	slotBuf := make([]byte, slotLen)
+	if _, err := io.ReadFull(conn, slotBuf); err != nil {
+		return Header{}, err
+	}
+	return Header{Size: size, CRC32: crc, ChunkSize: chunkSize, SessionID: sessionID, Secure: secure, Slot: string(slotBuf)}, nil
+}
+
+// WriteLastAcked writes how many leading chunks of a session the server
+// already holds, i.e. the index of the chunk the client should resume from.
+func WriteLastAcked(conn net.Conn, lastAcked int) error {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, uint32(lastAcked))
+	_, err := conn.Write(buf)
+	return err
+}
+
+// ReadLastAcked reads the reply WriteLastAcked sends.
+func ReadLastAcked(conn net.Conn) (int, error) {
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint32(buf)), nil
+}
+
+// WriteChunkFrame writes one `[index][length][payload][crc32(payload)]` frame.
+func WriteChunkFrame(conn net.Conn, index uint32, payload []byte) error {
+	frame := make([]byte, 4+4+len(payload)+4)
+	binary.BigEndian.PutUint32(frame[0:], index)
+	binary.BigEndian.PutUint32(frame[4:], uint32(len(payload)))
+	copy(frame[8:], payload)
+	binary.BigEndian.PutUint32(frame[8+len(payload):], crc32.ChecksumIEEE(payload))
+	_, err := conn.Write(frame)
+	return err
+}
+
+// ReadChunkFrame reads one chunk frame and reports whether its payload CRC32
+// matched, so the caller can NAK a corrupted chunk without aborting the
+// whole transfer.
+func ReadChunkFrame(conn net.Conn) (index uint32, payload []byte, crcOK bool, err error) {
+	head := make([]byte, 8)
+	if _, err = io.ReadFull(conn, head); err != nil {
+		return 0, nil, false, err
+	}
+	index = binary.BigEndian.Uint32(head[0:4])
+	length := binary.BigEndian.Uint32(head[4:8])
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(conn, payload); err != nil {
+		return 0, nil, false, err
+	}
+
+	crcBuf := make([]byte, 4)
+	if _, err = io.ReadFull(conn, crcBuf); err != nil {
+		return 0, nil, false, err
+	}
+	wantCRC := binary.BigEndian.Uint32(crcBuf)
+	return index, payload, crc32.ChecksumIEEE(payload) == wantCRC, nil
+}
+
+// readTrailingCRC reads the whole-image CRC32 the client sends after the
+// last chunk frame.
+func readTrailingCRC(conn net.Conn) (uint32, error) {
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(buf), nil
+}
+
+// ReadTrailingDigest reads the whole-image SHA-256 digest a secure session
+// sends in place of the trailing CRC32.
+func ReadTrailingDigest(conn net.Conn) ([]byte, error) {
+	buf := make([]byte, sha256Size)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+const sha256Size = 32
+
+// WriteInsecureHello tells a freshly-connected client whether this server
+// will accept a session with no -code at all.
+func WriteInsecureHello(conn net.Conn, insecureAllowed bool) error {
+	b := byte(0)
+	if insecureAllowed {
+		b = 1
+	}
+	_, err := conn.Write([]byte{b})
+	return err
+}
+
+// ReadInsecureHello reads the reply WriteInsecureHello sends.
+func ReadInsecureHello(conn net.Conn) (bool, error) {
+	buf := make([]byte, 1)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return false, err
+	}
+	return buf[0] == 1, nil
+}
+
+// WriteSecureMode tells the server whether the client is about to start a
+// secure.Handshake (1) or continue in cleartext (0).
+func WriteSecureMode(conn net.Conn, secure bool) error {
+	b := byte(0)
+	if secure {
+		b = 1
+	}
+	_, err := conn.Write([]byte{b})
+	return err
+}
+
+// ReadSecureMode reads the reply WriteSecureMode sends.
+func ReadSecureMode(conn net.Conn) (bool, error) {
+	buf := make([]byte, 1)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return false, err
+	}
+	return buf[0] == 1, nil
+}
+
+// WriteAck writes one `[type][index]` reply to the client.
+func WriteAck(conn net.Conn, typ AckType, index uint32) error {
+	buf := make([]byte, 1+4)
+	buf[0] = byte(typ)
+	binary.BigEndian.PutUint32(buf[1:], index)
+	_, err := conn.Write(buf)
+	return err
+}
+
+// ReadAck reads one `[type][index]` reply from the server.
+func ReadAck(conn net.Conn) (AckType, uint32, error) {
+	buf := make([]byte, 1+4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return 0, 0, err
+	}
+	return AckType(buf[0]), binary.BigEndian.Uint32(buf[1:]), nil
+}
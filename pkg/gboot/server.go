@@ -0,0 +1,228 @@
+package gboot
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"hash/crc32"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/wangchaoHZ/gboot/secure"
+)
+
+// Server accepts gboot upload sessions and writes each verified image to a
+// Sink built by NewSink, called with the session's slot (bootloader, kernel,
+// rootfs, ... or "" for a single-image send) so a multi-slot sink factory
+// can route each image to its own device or file. Concurrent sessions for
+// different session IDs are independent; reconnecting with the same session
+// ID resumes where the last connection for it left off, for as long as the
+// Server process runs.
+type Server struct {
+	Listener net.Listener
+	NewSink  func(sessionID, slot string) (Sink, error)
+
+	// Code, if set, is the shared PAKE password required to establish a
+	// secure session. InsecureAllowed controls whether clients with no
+	// -code may still connect in cleartext.
+	Code            string
+	InsecureAllowed bool
+
+	mu       sync.Mutex
+	resumeAt map[string]int    // sessionID -> chunks already received
+	partial  map[string][]byte // sessionID -> bytes received so far, across reconnects
+}
+
+// NewServer listens on addr and returns a Server that builds a fresh Sink
+// (via newSink) for each completed session. By default it only accepts
+// cleartext sessions; set Code and InsecureAllowed to change that.
+func NewServer(addr string, newSink func(sessionID, slot string) (Sink, error)) (*Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("gboot: failed to listen on %s: %w", addr, err)
+	}
+	return &Server{
+		Listener:        l,
+		NewSink:         newSink,
+		InsecureAllowed: true,
+		resumeAt:        make(map[string]int),
+		partial:         make(map[string][]byte),
+	}, nil
+}
+
+// Serve accepts connections until the listener is closed.
+func (s *Server) Serve() error {
+	for {
+		conn, err := s.Listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// negotiate advertises whether this server accepts cleartext sessions, then
+// either leaves conn as-is or runs a secure.Handshake on it, depending on
+// what the client asks for.
+func (s *Server) negotiate(conn net.Conn) (net.Conn, bool, error) {
+	if err := WriteInsecureHello(conn, s.InsecureAllowed); err != nil {
+		return nil, false, fmt.Errorf("failed to send hello: %w", err)
+	}
+	wantsSecure, err := ReadSecureMode(conn)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read secure mode: %w", err)
+	}
+
+	if !wantsSecure {
+		if !s.InsecureAllowed {
+			return nil, false, fmt.Errorf("client requested a cleartext session, which this server does not allow")
+		}
+		return conn, false, nil
+	}
+	if s.Code == "" {
+		return nil, false, fmt.Errorf("client requested a secure session but this server has no -code configured")
+	}
+	secured, err := secure.Handshake(conn, s.Code, false)
+	if err != nil {
+		return nil, false, err
+	}
+	return secured, true, nil
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	conn, isSecure, err := s.negotiate(conn)
+	if err != nil {
+		log.Printf("gboot: session setup failed: %v", err)
+		return
+	}
+
+	h, err := ReadHeader(conn)
+	if err != nil {
+		log.Printf("gboot: failed to read session header: %v", err)
+		return
+	}
+	if h.Size < 0 || h.Size > MaxImageSize {
+		log.Printf("gboot: session %s: rejecting header with bogus size %d", h.SessionID, h.Size)
+		return
+	}
+
+	s.mu.Lock()
+	lastAcked := s.resumeAt[h.SessionID]
+	received, ok := s.partial[h.SessionID]
+	if !ok {
+		received = make([]byte, h.Size)
+		s.partial[h.SessionID] = received
+	}
+	s.mu.Unlock()
+
+	if err := WriteLastAcked(conn, lastAcked); err != nil {
+		log.Printf("gboot: failed to send resume offset: %v", err)
+		return
+	}
+
+	chunkSize := int(h.ChunkSize)
+	if chunkSize <= 0 {
+		chunkSize = ChunkSize
+	}
+	totalChunks := (int(h.Size) + chunkSize - 1) / chunkSize
+
+	gotChunk := make(map[uint32]bool, totalChunks)
+	acked := lastAcked
+
+	for acked < totalChunks {
+		index, payload, crcOK, err := ReadChunkFrame(conn)
+		if err != nil {
+			log.Printf("gboot: session %s: failed to read chunk: %v", h.SessionID, err)
+			return
+		}
+		if !crcOK {
+			if err := WriteAck(conn, AckNAK, index); err != nil {
+				log.Printf("gboot: session %s: failed to send NAK: %v", h.SessionID, err)
+				return
+			}
+			continue
+		}
+
+		start := int(index) * chunkSize
+		copy(received[start:], payload)
+		gotChunk[index] = true
+
+		if err := WriteAck(conn, AckOK, index); err != nil {
+			log.Printf("gboot: session %s: failed to send ACK: %v", h.SessionID, err)
+			return
+		}
+
+		if int(index) == acked {
+			for gotChunk[uint32(acked)] && acked < totalChunks {
+				acked++
+			}
+			s.mu.Lock()
+			s.resumeAt[h.SessionID] = acked
+			s.mu.Unlock()
+		}
+	}
+
+	if isSecure != h.Secure {
+		log.Printf("gboot: session %s: secure-mode mismatch between negotiation and header", h.SessionID)
+		return
+	}
+
+	if ok, err := verifyTrailer(conn, received, h.CRC32, isSecure); err != nil {
+		log.Printf("gboot: session %s: failed to read trailing checksum: %v", h.SessionID, err)
+		return
+	} else if !ok {
+		conn.Write([]byte(CRCBadMsg))
+		log.Printf("gboot: session %s: checksum mismatch, image rejected", h.SessionID)
+		return
+	}
+
+	sink, err := s.NewSink(h.SessionID, h.Slot)
+	if err != nil {
+		conn.Write([]byte(CRCBadMsg))
+		log.Printf("gboot: session %s: failed to open sink: %v", h.SessionID, err)
+		return
+	}
+	if _, err := sink.WriteAt(received, 0); err != nil {
+		conn.Write([]byte(CRCBadMsg))
+		log.Printf("gboot: session %s: failed to write to sink: %v", h.SessionID, err)
+		sink.Close()
+		return
+	}
+	if err := sink.Close(); err != nil {
+		conn.Write([]byte(CRCBadMsg))
+		log.Printf("gboot: session %s: sink finalization failed: %v", h.SessionID, err)
+		return
+	}
+
+	s.mu.Lock()
+	delete(s.resumeAt, h.SessionID)
+	delete(s.partial, h.SessionID)
+	s.mu.Unlock()
+
+	conn.Write([]byte(CRCOkMsg))
+	log.Printf("gboot: session %s: image verified and written (%d bytes)", h.SessionID, h.Size)
+}
+
+// verifyTrailer checks the whole-image checksum the client sends after the
+// last chunk frame: a CRC32 for a cleartext session, or a SHA-256 digest
+// for a secure one (the AEAD already authenticates the bytes in flight; the
+// digest guards against the two sides somehow deriving different keys).
+func verifyTrailer(conn net.Conn, received []byte, headerCRC uint32, isSecure bool) (bool, error) {
+	if !isSecure {
+		trailingCRC, err := readTrailingCRC(conn)
+		if err != nil {
+			return false, err
+		}
+		return trailingCRC == headerCRC && trailingCRC == crc32.ChecksumIEEE(received), nil
+	}
+
+	digest, err := ReadTrailingDigest(conn)
+	if err != nil {
+		return false, err
+	}
+	want := sha256.Sum256(received)
+	return bytes.Equal(digest, want[:]), nil
+}
@@ -0,0 +1,240 @@
+package gboot
+
+import (
+	"bytes"
+	"hash/crc32"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClientServerLoopback(t *testing.T) {
+	dir := t.TempDir()
+	sinkPath := filepath.Join(dir, "image.bin")
+
+	srv, err := NewServer("127.0.0.1:0", func(sessionID, slot string) (Sink, error) {
+		return NewFileSink(sinkPath)
+	})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer srv.Listener.Close()
+	go srv.Serve()
+
+	data := bytes.Repeat([]byte("gboot-test-payload"), 100)
+	dataCRC := crc32.ChecksumIEEE(data)
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := ReadInsecureHello(conn); err != nil {
+		t.Fatalf("ReadInsecureHello: %v", err)
+	}
+	if err := WriteSecureMode(conn, false); err != nil {
+		t.Fatalf("WriteSecureMode: %v", err)
+	}
+
+	client := NewClient(conn)
+	lastAcked, err := client.Negotiate(int64(len(data)), dataCRC, "test-session", false, "")
+	if err != nil {
+		t.Fatalf("Negotiate: %v", err)
+	}
+	if lastAcked != 0 {
+		t.Fatalf("expected a fresh session to resume from 0, got %d", lastAcked)
+	}
+	if err := client.SendChunks(data, lastAcked, nil); err != nil {
+		t.Fatalf("SendChunks: %v", err)
+	}
+	if err := client.Finish(dataCRC); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+
+	got, err := os.ReadFile(sinkPath)
+	if err != nil {
+		t.Fatalf("ReadFile(sink): %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("sink content mismatch: got %d bytes, want %d bytes", len(got), len(data))
+	}
+}
+
+// corruptFirstChunk flips a payload byte on the first Write that looks like
+// a chunk frame (index+length+payload+crc32), desyncing the payload from
+// its already-computed trailing CRC32 so the server NAKs it. Every later
+// Write, including the client's retransmit of that same chunk, passes
+// through untouched.
+type corruptFirstChunk struct {
+	net.Conn
+	done bool
+}
+
+func (c *corruptFirstChunk) Write(b []byte) (int, error) {
+	if !c.done && len(b) > 8 {
+		corrupted := append([]byte(nil), b...)
+		corrupted[8] ^= 0xFF
+		c.done = true
+		if _, err := c.Conn.Write(corrupted); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	return c.Conn.Write(b)
+}
+
+func TestClientServerRetransmitsOnNAK(t *testing.T) {
+	dir := t.TempDir()
+	sinkPath := filepath.Join(dir, "image.bin")
+
+	srv, err := NewServer("127.0.0.1:0", func(sessionID, slot string) (Sink, error) {
+		return NewFileSink(sinkPath)
+	})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer srv.Listener.Close()
+	go srv.Serve()
+
+	data := bytes.Repeat([]byte("gboot-nak-test-payload"), 100)
+	dataCRC := crc32.ChecksumIEEE(data)
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := ReadInsecureHello(conn); err != nil {
+		t.Fatalf("ReadInsecureHello: %v", err)
+	}
+	if err := WriteSecureMode(conn, false); err != nil {
+		t.Fatalf("WriteSecureMode: %v", err)
+	}
+
+	client := NewClient(conn)
+	lastAcked, err := client.Negotiate(int64(len(data)), dataCRC, "nak-test-session", false, "")
+	if err != nil {
+		t.Fatalf("Negotiate: %v", err)
+	}
+
+	client.Conn = &corruptFirstChunk{Conn: client.Conn}
+	if err := client.SendChunks(data, lastAcked, nil); err != nil {
+		t.Fatalf("SendChunks: %v", err)
+	}
+	if err := client.Finish(dataCRC); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+
+	got, err := os.ReadFile(sinkPath)
+	if err != nil {
+		t.Fatalf("ReadFile(sink): %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("sink content mismatch after NAK/retransmit: got %d bytes, want %d bytes", len(got), len(data))
+	}
+}
+
+func TestClientServerResumeAfterPartialTransfer(t *testing.T) {
+	dir := t.TempDir()
+	sinkPath := filepath.Join(dir, "image.bin")
+
+	srv, err := NewServer("127.0.0.1:0", func(sessionID, slot string) (Sink, error) {
+		return NewFileSink(sinkPath)
+	})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer srv.Listener.Close()
+	go srv.Serve()
+
+	data := bytes.Repeat([]byte("gboot-resume-test-payload"), 200)
+	dataCRC := crc32.ChecksumIEEE(data)
+	totalChunks := (len(data) + ChunkSize - 1) / ChunkSize
+	if totalChunks < 4 {
+		t.Fatalf("test payload too small to exercise a partial resume (%d chunks)", totalChunks)
+	}
+	sendHalf := totalChunks / 2
+	const sessionID = "resume-test-session"
+
+	func() {
+		conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+		if err != nil {
+			t.Fatalf("Dial: %v", err)
+		}
+		defer conn.Close()
+
+		if _, err := ReadInsecureHello(conn); err != nil {
+			t.Fatalf("ReadInsecureHello: %v", err)
+		}
+		if err := WriteSecureMode(conn, false); err != nil {
+			t.Fatalf("WriteSecureMode: %v", err)
+		}
+
+		client := NewClient(conn)
+		lastAcked, err := client.Negotiate(int64(len(data)), dataCRC, sessionID, false, "")
+		if err != nil {
+			t.Fatalf("Negotiate: %v", err)
+		}
+		if lastAcked != 0 {
+			t.Fatalf("expected a fresh session to resume from 0, got %d", lastAcked)
+		}
+
+		for i := 0; i < sendHalf; i++ {
+			start := i * ChunkSize
+			end := start + ChunkSize
+			if end > len(data) {
+				end = len(data)
+			}
+			if err := WriteChunkFrame(conn, uint32(i), data[start:end]); err != nil {
+				t.Fatalf("WriteChunkFrame(%d): %v", i, err)
+			}
+			typ, index, err := ReadAck(conn)
+			if err != nil {
+				t.Fatalf("ReadAck(%d): %v", i, err)
+			}
+			if typ != AckOK || int(index) != i {
+				t.Fatalf("unexpected ack for chunk %d: type=%v index=%d", i, typ, index)
+			}
+		}
+		// conn closes here without ever sending the trailing CRC32/Finish.
+	}()
+
+	conn2, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn2.Close()
+
+	if _, err := ReadInsecureHello(conn2); err != nil {
+		t.Fatalf("ReadInsecureHello: %v", err)
+	}
+	if err := WriteSecureMode(conn2, false); err != nil {
+		t.Fatalf("WriteSecureMode: %v", err)
+	}
+
+	client2 := NewClient(conn2)
+	lastAcked, err := client2.Negotiate(int64(len(data)), dataCRC, sessionID, false, "")
+	if err != nil {
+		t.Fatalf("Negotiate: %v", err)
+	}
+	if lastAcked != sendHalf {
+		t.Fatalf("expected to resume from chunk %d, got %d", sendHalf, lastAcked)
+	}
+	if err := client2.SendChunks(data, lastAcked, nil); err != nil {
+		t.Fatalf("SendChunks: %v", err)
+	}
+	if err := client2.Finish(dataCRC); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+
+	got, err := os.ReadFile(sinkPath)
+	if err != nil {
+		t.Fatalf("ReadFile(sink): %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("sink content mismatch after resume: got %d bytes, want %d bytes", len(got), len(data))
+	}
+}
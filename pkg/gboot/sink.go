@@ -0,0 +1,70 @@
+package gboot
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Sink is where a Server writes a verified image once a session completes:
+// a raw block device, a plain file, or a hook to exec with the image.
+type Sink interface {
+	io.WriterAt
+	Close() error
+}
+
+// FileSink writes the image directly to path — a plain file or a raw block
+// device node (e.g. /dev/mmcblk0p1) are opened the same way on Linux.
+type FileSink struct {
+	f *os.File
+}
+
+// NewFileSink opens path for writing, creating it if it doesn't already
+// exist (block device nodes always do; plain files may not).
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("gboot: failed to open sink %s: %w", path, err)
+	}
+	return &FileSink{f: f}, nil
+}
+
+func (s *FileSink) WriteAt(p []byte, off int64) (int, error) { return s.f.WriteAt(p, off) }
+func (s *FileSink) Close() error                             { return s.f.Close() }
+
+// ExecSink buffers the image in a temp file and, once the transfer is
+// verified, execs hookPath with the temp file's path and the image's slot
+// as arguments — e.g. `--on-complete=/usr/local/bin/flash.sh`.
+type ExecSink struct {
+	hookPath string
+	slot     string
+	tmp      *os.File
+}
+
+// NewExecSink creates a temp file to receive the image and remembers the
+// hook to run, and the slot to pass it, once it's complete.
+func NewExecSink(hookPath, slot string) (*ExecSink, error) {
+	tmp, err := os.CreateTemp("", "gboot-image-*")
+	if err != nil {
+		return nil, fmt.Errorf("gboot: failed to create temp file for exec sink: %w", err)
+	}
+	return &ExecSink{hookPath: hookPath, slot: slot, tmp: tmp}, nil
+}
+
+func (s *ExecSink) WriteAt(p []byte, off int64) (int, error) { return s.tmp.WriteAt(p, off) }
+
+// Close runs the completion hook against the buffered image, then removes it.
+func (s *ExecSink) Close() error {
+	defer os.Remove(s.tmp.Name())
+	if err := s.tmp.Close(); err != nil {
+		return err
+	}
+	cmd := exec.Command(s.hookPath, s.tmp.Name(), s.slot)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("gboot: completion hook %s failed: %w", s.hookPath, err)
+	}
+	return nil
+}
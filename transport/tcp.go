@@ -0,0 +1,237 @@
+package transport
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+	"github.com/wangchaoHZ/gboot/pkg/gboot"
+	"github.com/wangchaoHZ/gboot/secure"
+)
+
+const (
+	serverPort    = 5000
+	stateDirName  = ".gboot"
+	stateFileName = "state.json"
+)
+
+// sessionState is persisted to ~/.gboot/state.json so an interrupted upload
+// can be resumed by rerunning the same command.
+type sessionState struct {
+	SessionID string `json:"sessionID"`
+	DataCRC   uint32 `json:"firmwareCRC"`
+	LastAcked int    `json:"lastAcked"`
+}
+
+func stateFilePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(home, stateDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, stateFileName), nil
+}
+
+func loadSessionState(dataCRC uint32) *sessionState {
+	path, err := stateFilePath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var st sessionState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil
+	}
+	if st.DataCRC != dataCRC {
+		return nil
+	}
+	return &st
+}
+
+func saveSessionState(st *sessionState) error {
+	path, err := stateFilePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func clearSessionState() {
+	path, err := stateFilePath()
+	if err != nil {
+		return
+	}
+	os.Remove(path)
+}
+
+func newSessionID(data []byte) string {
+	h := sha256.New()
+	h.Write(data)
+	fmt.Fprintf(h, "%d", time.Now().UnixNano())
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// TCP is gboot's original backend: the pkg/gboot session protocol over a
+// plain TCP connection, with resume support via ~/.gboot/state.json. If
+// Code is set, the session is authenticated and encrypted via a PAKE
+// handshake (see the secure package) before any firmware data is sent.
+type TCP struct {
+	ServerIP string
+	Port     int
+	Code     string
+}
+
+// NewTCP returns a TCP transport targeting serverIP on the default gboot port.
+func NewTCP(serverIP string) *TCP {
+	return &TCP{ServerIP: serverIP, Port: serverPort}
+}
+
+func (t *TCP) dial(ctx context.Context) (net.Conn, error) {
+	port := t.Port
+	if port == 0 {
+		port = serverPort
+	}
+	addr := net.JoinHostPort(t.ServerIP, fmt.Sprint(port))
+	for {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			fmt.Printf("Connected to %s\n", addr)
+			return conn, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		fmt.Printf("Connection failed: %v, retrying in 3 seconds...\n", err)
+		time.Sleep(3 * time.Second)
+	}
+}
+
+// Send implements Transport by reading r fully (the resumable protocol needs
+// random access to retransmit chunks) and running it through the session.
+func (t *TCP) Send(ctx context.Context, name, slot string, r io.Reader, size int64) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	dataCRC32 := crc32.ChecksumIEEE(data)
+	fmt.Printf("Sending %s, size: %d bytes\n", name, len(data))
+	fmt.Printf("Computed CRC32: 0x%08X\n", dataCRC32)
+
+	var sessionID string
+	if prev := loadSessionState(dataCRC32); prev != nil {
+		sessionID = prev.SessionID
+	} else {
+		sessionID = newSessionID(data)
+	}
+
+	conn, err := t.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return t.transfer(conn, data, dataCRC32, sessionID, slot)
+}
+
+// transfer runs the session over an already-connected conn, updating the
+// progress bar and persisted resume state as chunks are acknowledged.
+func (t *TCP) transfer(conn net.Conn, data []byte, dataCRC32 uint32, sessionID, slot string) error {
+	conn, isSecure, err := t.secureUpgrade(conn)
+	if err != nil {
+		return err
+	}
+
+	client := gboot.NewClient(conn)
+
+	lastAcked, err := client.Negotiate(int64(len(data)), dataCRC32, sessionID, isSecure, slot)
+	if err != nil {
+		return fmt.Errorf("failed to negotiate session with server: %w", err)
+	}
+	if lastAcked > 0 {
+		fmt.Printf("Resuming session %s from chunk %d\n", sessionID, lastAcked)
+	}
+
+	bar := progressbar.NewOptions(len(data),
+		progressbar.OptionSetWidth(50),
+		progressbar.OptionSetDescription("Uploading..."),
+		progressbar.OptionSetRenderBlankState(true),
+	)
+	bar.Set(lastAcked * gboot.ChunkSize)
+
+	onAcked := func(ackedBytes, frontier int) {
+		bar.Add(ackedBytes)
+		saveSessionState(&sessionState{SessionID: sessionID, DataCRC: dataCRC32, LastAcked: frontier})
+	}
+	if err := client.SendChunks(data, lastAcked, onAcked); err != nil {
+		return err
+	}
+
+	if isSecure {
+		digest := sha256.Sum256(data)
+		fmt.Printf("Upload completed. Verifying SHA-256 over the secure channel...\n")
+		err = client.FinishSecure(digest[:])
+	} else {
+		fmt.Printf("Upload completed. Sent CRC32: 0x%08X\n", dataCRC32)
+		err = client.Finish(dataCRC32)
+	}
+	if err != nil {
+		fmt.Printf("\n")
+		return err
+	}
+
+	fmt.Printf("\n")
+	fmt.Printf("Success: verification passed, transfer complete.\n")
+	clearSessionState()
+	return nil
+}
+
+// secureUpgrade negotiates whether this session should run over a
+// secure.Conn: it reads the server's hello, tells it whether t.Code is set,
+// and runs the PAKE handshake if both sides agree to.
+func (t *TCP) secureUpgrade(conn net.Conn) (net.Conn, bool, error) {
+	insecureAllowed, err := gboot.ReadInsecureHello(conn)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read server hello: %w", err)
+	}
+
+	wantsSecure := t.Code != ""
+	if !wantsSecure && !insecureAllowed {
+		return nil, false, fmt.Errorf("server requires a secure session; pass -code")
+	}
+	if err := gboot.WriteSecureMode(conn, wantsSecure); err != nil {
+		return nil, false, fmt.Errorf("failed to send secure mode: %w", err)
+	}
+	if !wantsSecure {
+		return conn, false, nil
+	}
+
+	secured, err := secure.Handshake(conn, t.Code, true)
+	if err != nil {
+		return nil, false, err
+	}
+	fmt.Println("Secure session established.")
+	return secured, true, nil
+}
@@ -0,0 +1,249 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// TFTP opcodes (RFC 1350).
+const (
+	opRRQ   uint16 = 1
+	opDATA  uint16 = 3
+	opACK   uint16 = 4
+	opERROR uint16 = 5
+	opOACK  uint16 = 6
+)
+
+const (
+	tftpPort          = 69
+	defaultBlksize    = 512
+	negotiatedBlksize = 1428
+	negotiatedWindow  = 16
+	tftpRetryTimeout  = 2 * time.Second
+	tftpMaxRetries    = 5
+)
+
+// TFTP is a server-mode backend: gboot listens for a device's bootloader to
+// pull the image via TFTP (RFC 1350) instead of pushing it over TCP. It
+// negotiates a large block size and window size (RFC 2347/2348/7440) so
+// throughput doesn't collapse into stop-and-wait on high-latency links.
+type TFTP struct {
+	// Addr is the UDP address to listen on, e.g. ":69". Defaults to
+	// ":69" if empty.
+	Addr string
+}
+
+// NewTFTP returns a TFTP server-mode transport listening on addr (":69" if empty).
+func NewTFTP(addr string) *TFTP {
+	return &TFTP{Addr: addr}
+}
+
+// Send waits for the device to issue a TFTP RRQ for name, then streams r to
+// it. slot is ignored: TFTP is a pull-based, RRQ-filename-addressed protocol
+// with no session header to carry slot metadata, so a bootloader pulling a
+// manifest's images must already request each by its distinct name.
+func (t *TFTP) Send(ctx context.Context, name, slot string, r io.Reader, size int64) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	addr := t.Addr
+	if addr == "" {
+		addr = fmt.Sprintf(":%d", tftpPort)
+	}
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return fmt.Errorf("invalid TFTP listen address %q: %w", addr, err)
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for TFTP requests on %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	fmt.Printf("Waiting for TFTP RRQ for %q on %s...\n", name, addr)
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	for {
+		buf := make([]byte, 2048)
+		n, peer, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return fmt.Errorf("TFTP listen failed: %w", err)
+		}
+		req, ok := parseRRQ(buf[:n])
+		if !ok || req.filename != name {
+			continue
+		}
+		return serveTFTP(peer, req, data)
+	}
+}
+
+type rrq struct {
+	filename string
+	options  map[string]string
+}
+
+// parseRRQ decodes an opcode-1 read request: `[opcode][filename]\0[mode]\0[opt]\0[val]\0...`.
+func parseRRQ(pkt []byte) (rrq, bool) {
+	if len(pkt) < 4 || binary.BigEndian.Uint16(pkt[0:2]) != opRRQ {
+		return rrq{}, false
+	}
+	fields := bytes.Split(pkt[2:], []byte{0})
+	if len(fields) < 2 {
+		return rrq{}, false
+	}
+	req := rrq{filename: string(fields[0]), options: map[string]string{}}
+	// fields[1] is the transfer mode (octet/netascii); options follow in pairs.
+	for i := 2; i+1 < len(fields); i += 2 {
+		if len(fields[i]) == 0 {
+			break
+		}
+		req.options[string(bytes.ToLower(fields[i]))] = string(fields[i+1])
+	}
+	return req, true
+}
+
+// serveTFTP negotiates options via OACK and streams data to peer in
+// blksize-sized DATA packets, windowsize-many at a time.
+func serveTFTP(peer *net.UDPAddr, req rrq, data []byte) error {
+	conn, err := net.DialUDP("udp", nil, peer)
+	if err != nil {
+		return fmt.Errorf("failed to open transfer socket to %s: %w", peer, err)
+	}
+	defer conn.Close()
+
+	blksize := defaultBlksize
+	window := 1
+	oack := map[string]string{}
+
+	if _, ok := req.options["blksize"]; ok {
+		blksize = negotiatedBlksize
+		oack["blksize"] = strconv.Itoa(blksize)
+	}
+	if _, ok := req.options["tsize"]; ok {
+		oack["tsize"] = strconv.Itoa(len(data))
+	}
+	if _, ok := req.options["windowsize"]; ok {
+		window = negotiatedWindow
+		oack["windowsize"] = strconv.Itoa(window)
+	}
+
+	totalBlocks := (len(data) + blksize - 1) / blksize
+	if totalBlocks == 0 {
+		totalBlocks = 1 // an empty file is still one (zero-length) final block
+	}
+
+	blockAt := func(n int) []byte {
+		start := (n - 1) * blksize
+		end := start + blksize
+		if end > len(data) {
+			end = len(data)
+		}
+		if start > len(data) {
+			start = len(data)
+		}
+		return data[start:end]
+	}
+
+	if len(oack) > 0 {
+		if err := writeOACK(conn, oack); err != nil {
+			return fmt.Errorf("failed to send OACK: %w", err)
+		}
+	}
+
+	acked := 0 // highest block number the client has acknowledged
+	for acked < totalBlocks {
+		windowEnd := acked + window
+		if windowEnd > totalBlocks {
+			windowEnd = totalBlocks
+		}
+
+		for b := acked + 1; b <= windowEnd; b++ {
+			if err := writeDATA(conn, uint16(b), blockAt(b)); err != nil {
+				return fmt.Errorf("failed to send block %d: %w", b, err)
+			}
+		}
+
+		ackNum, err := readACKWithRetry(conn, acked+1, windowEnd, blockAt)
+		if err != nil {
+			return fmt.Errorf("TFTP transfer failed: %w", err)
+		}
+		if ackNum > acked {
+			acked = ackNum
+		}
+	}
+
+	fmt.Println("TFTP transfer complete.")
+	return nil
+}
+
+// unwrapBlockNum recovers the real, ever-increasing block number a 16-bit
+// wire ACK refers to. TFTP block numbers (RFC 1350) are transmitted as a
+// uint16 and wrap back to 0 after 65535, so past ~93 MB at the negotiated
+// 1428-byte blksize, a bare `int(wire)` ACK no longer compares correctly
+// against the real block count. windowStart-1 (no progress on this window
+// yet) through windowEnd (the window completed) is the only range a
+// well-formed ACK can name, and uint16(b) wraps the same way the wire value
+// did, so a direct scan finds the unique real block number that matches.
+func unwrapBlockNum(wire uint16, windowStart, windowEnd int) (int, bool) {
+	for b := windowStart - 1; b <= windowEnd; b++ {
+		if uint16(b) == wire {
+			return b, true
+		}
+	}
+	return 0, false
+}
+
+func readACKWithRetry(conn *net.UDPConn, windowStart, windowEnd int, blockAt func(int) []byte) (int, error) {
+	for attempt := 0; attempt < tftpMaxRetries; attempt++ {
+		conn.SetReadDeadline(time.Now().Add(tftpRetryTimeout))
+		buf := make([]byte, 4)
+		n, err := conn.Read(buf)
+		if err == nil && n == 4 && binary.BigEndian.Uint16(buf[0:2]) == opACK {
+			if ackNum, ok := unwrapBlockNum(binary.BigEndian.Uint16(buf[2:4]), windowStart, windowEnd); ok {
+				return ackNum, nil
+			}
+		}
+		// Timeout, a stray packet, or an ACK outside the current window:
+		// resend the current window.
+		for b := windowStart; b <= windowEnd; b++ {
+			if werr := writeDATA(conn, uint16(b), blockAt(b)); werr != nil {
+				return 0, werr
+			}
+		}
+	}
+	return 0, fmt.Errorf("no ACK for blocks %d-%d after %d retries", windowStart, windowEnd, tftpMaxRetries)
+}
+
+func writeDATA(conn *net.UDPConn, block uint16, payload []byte) error {
+	pkt := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint16(pkt[0:2], opDATA)
+	binary.BigEndian.PutUint16(pkt[2:4], block)
+	copy(pkt[4:], payload)
+	_, err := conn.Write(pkt)
+	return err
+}
+
+func writeOACK(conn *net.UDPConn, opts map[string]string) error {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, opOACK)
+	for k, v := range opts {
+		buf.WriteString(k)
+		buf.WriteByte(0)
+		buf.WriteString(v)
+		buf.WriteByte(0)
+	}
+	_, err := conn.Write(buf.Bytes())
+	return err
+}
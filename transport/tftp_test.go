@@ -0,0 +1,30 @@
+package transport
+
+import "testing"
+
+func TestUnwrapBlockNum(t *testing.T) {
+	tests := []struct {
+		name                   string
+		wire                   uint16
+		windowStart, windowEnd int
+		want                   int
+		wantOK                 bool
+	}{
+		{"first window, ack of OACK", 0, 1, 16, 0, true},
+		{"first window, mid-window ack", 8, 1, 16, 8, true},
+		{"ack outside the window is rejected", 40, 1, 16, 0, false},
+		{"window straddling the 16-bit wraparound", 4, 65530, 65541, 65540, true},
+		{"window entirely past the wraparound", 70, 65600, 65610, 65606, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := unwrapBlockNum(tt.wire, tt.windowStart, tt.windowEnd)
+			if ok != tt.wantOK {
+				t.Fatalf("unwrapBlockNum(%d, %d, %d) ok = %v, want %v", tt.wire, tt.windowStart, tt.windowEnd, ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Fatalf("unwrapBlockNum(%d, %d, %d) = %d, want %d", tt.wire, tt.windowStart, tt.windowEnd, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+// Package transport implements the pluggable wire transports gboot can use
+// to push an image to a device: the original TCP session protocol, and a
+// TFTP server-mode backend for bootloaders that only speak TFTP.
+package transport
+
+import (
+	"context"
+	"io"
+)
+
+// Transport sends one named image of the given size to a device. slot
+// identifies which manifest slot (bootloader, kernel, rootfs, ...) the
+// image belongs to, so a slot-aware server can route it to the right sink;
+// it's empty for a single-image (non-manifest) send.
+type Transport interface {
+	Send(ctx context.Context, name, slot string, r io.Reader, size int64) error
+}